@@ -0,0 +1,109 @@
+package tcp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+)
+
+// tlsRecordTypeHandshake is byte 0 of a TLS record header for a handshake message (the ContentType field).
+const tlsRecordTypeHandshake = 0x16
+
+// tlsMajorVersionSSL3 is byte 1 of a TLS record header, the major version shared by SSLv3 and all TLS 1.x
+// versions, which is what every TLS client negotiates with today.
+const tlsMajorVersionSSL3 = 0x03
+
+// AutoListener wraps a net.Listener and, for each accepted connection, peeks at the first two bytes to decide
+// whether it is a TLS handshake or a plaintext HTTP/1.1 request, so that a single port can serve both. This is
+// useful for health-check probes, local reverse proxies, and non-TLS cluster join flows that sit behind a load
+// balancer which already terminates TLS.
+type AutoListener struct {
+	net.Listener
+
+	tlsConfig      *tls.Config
+	allowPlaintext bool
+	timeoutConfig  TimeoutConfig
+}
+
+// NewAutoListener returns an AutoListener that serves TLS connections using tlsConfig, wrapping inner.
+// Plaintext connections are only accepted if allowPlaintext is true; otherwise Accept closes and skips them,
+// so that the plaintext fallback stays opt-in and isn't enabled by accident on internet-facing endpoints.
+// timeoutConfig is applied to every accepted connection via SetTimeouts. No daemon config keys feed it; it is
+// whatever the caller passes.
+func NewAutoListener(inner net.Listener, tlsConfig *tls.Config, allowPlaintext bool, timeoutConfig TimeoutConfig) *AutoListener {
+	return &AutoListener{Listener: inner, tlsConfig: tlsConfig, allowPlaintext: allowPlaintext, timeoutConfig: timeoutConfig}
+}
+
+// Accept waits for the next incoming connection, peeks at its first two bytes to determine whether it is TLS
+// or plaintext, and returns a conn of the appropriate type. SetTimeouts is applied to the raw TCP connection
+// before the TLS/plaintext branch so the tuning is identical regardless of which protocol is detected.
+//
+// A connection that fails to set up (SetTimeouts error, peek error such as the peer disconnecting before
+// sending anything, or a plaintext probe when plaintext isn't allowed) is closed and skipped rather than
+// returned as an error: net/http's Server.Serve treats any error without a Temporary() bool true method as
+// fatal and shuts down the whole listener, so returning one of those here for what is normally just a
+// single bad/probing connection (e.g. a health check) would take down the entire API for every other client.
+func (l *AutoListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			err = SetTimeouts(tcpConn, l.timeoutConfig)
+			if err != nil {
+				_ = conn.Close()
+				continue
+			}
+		}
+
+		peeked := newPeekedConn(conn)
+
+		header, err := peeked.Peek(2)
+		if err != nil {
+			_ = conn.Close()
+			continue
+		}
+
+		if header[0] == tlsRecordTypeHandshake && header[1] == tlsMajorVersionSSL3 {
+			return tls.Server(peeked, l.tlsConfig), nil
+		}
+
+		if !l.allowPlaintext {
+			_ = conn.Close()
+			continue
+		}
+
+		return peeked, nil
+	}
+}
+
+// peekedConn is a net.Conn wrapper that lets callers peek at the first bytes of a connection and then
+// continue reading the stream normally, including the bytes that were already peeked.
+type peekedConn struct {
+	net.Conn
+
+	reader *bufio.Reader
+}
+
+// newPeekedConn wraps conn in a bufio.Reader so its first bytes can be inspected without consuming them.
+func newPeekedConn(conn net.Conn) *peekedConn {
+	return &peekedConn{Conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// Peek returns the next n bytes without advancing the reader, buffering them for the next Read calls.
+func (c *peekedConn) Peek(n int) ([]byte, error) {
+	return c.reader.Peek(n)
+}
+
+// Read reads from the buffered reader so that any peeked bytes are returned before reading more from the
+// underlying connection.
+func (c *peekedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// Unwrap returns the net.Conn this peekedConn wraps, so ExtractConn can see through it to the real socket.
+func (c *peekedConn) Unwrap() net.Conn {
+	return c.Conn
+}
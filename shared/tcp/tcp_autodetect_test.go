@@ -0,0 +1,228 @@
+package tcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestAutoListener_TLSDetected dials with a TLS client and checks the AutoListener hands back a *tls.Conn
+// that completes a real handshake and round-trips data.
+func TestAutoListener_TLSDetected(t *testing.T) {
+	ln, addr := newTCPListener(t)
+	al := NewAutoListener(ln, newTestTLSConfig(t), false, DefaultTimeoutConfig())
+
+	acceptCh := make(chan net.Conn, 1)
+	acceptErrCh := make(chan error, 1)
+
+	go func() {
+		conn, err := al.Accept()
+		if err != nil {
+			acceptErrCh <- err
+			return
+		}
+
+		acceptCh <- conn
+	}()
+
+	rawClient, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rawClient.Close() })
+
+	client := tls.Client(rawClient, &tls.Config{InsecureSkipVerify: true})
+
+	// client.Write blocks until the TLS handshake completes, so it must run concurrently with the server
+	// side below rather than before it, or the two ends deadlock waiting on each other.
+	writeErrCh := make(chan error, 1)
+
+	go func() {
+		_, err := client.Write([]byte("ping"))
+		writeErrCh <- err
+	}()
+
+	var server net.Conn
+
+	select {
+	case server = <-acceptCh:
+	case err := <-acceptErrCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Accept did not return")
+	}
+
+	t.Cleanup(func() { _ = server.Close() })
+
+	require.IsType(t, &tls.Conn{}, server)
+
+	buf := make([]byte, 4)
+	_, err = server.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buf))
+
+	select {
+	case err := <-writeErrCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("client handshake/write did not complete")
+	}
+}
+
+// TestAutoListener_PlaintextAllowed dials with a plain TCP client sending a non-TLS payload and checks the
+// AutoListener hands back a conn that still sees the bytes already consumed while peeking.
+func TestAutoListener_PlaintextAllowed(t *testing.T) {
+	ln, addr := newTCPListener(t)
+	al := NewAutoListener(ln, newTestTLSConfig(t), true, DefaultTimeoutConfig())
+
+	acceptCh := make(chan net.Conn, 1)
+	acceptErrCh := make(chan error, 1)
+
+	go func() {
+		conn, err := al.Accept()
+		if err != nil {
+			acceptErrCh <- err
+			return
+		}
+
+		acceptCh <- conn
+	}()
+
+	client, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	_, err = client.Write([]byte("GET / HTTP/1.1\r\n"))
+	require.NoError(t, err)
+
+	var server net.Conn
+
+	select {
+	case server = <-acceptCh:
+	case err := <-acceptErrCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Accept did not return")
+	}
+
+	t.Cleanup(func() { _ = server.Close() })
+
+	buf := make([]byte, 16)
+	n, err := server.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "GET / HTTP/1.1\r\n", string(buf[:n]))
+}
+
+// TestAutoListener_PlaintextDisallowedIsSwallowed checks that a plaintext probe is closed and skipped rather
+// than being returned as an Accept error or killing the listener, and that a single Accept call goes on to
+// return the next, well-formed TLS connection.
+func TestAutoListener_PlaintextDisallowedIsSwallowed(t *testing.T) {
+	ln, addr := newTCPListener(t)
+	al := NewAutoListener(ln, newTestTLSConfig(t), false, DefaultTimeoutConfig())
+
+	acceptCh := make(chan net.Conn, 1)
+	acceptErrCh := make(chan error, 1)
+
+	go func() {
+		conn, err := al.Accept()
+		if err != nil {
+			acceptErrCh <- err
+			return
+		}
+
+		acceptCh <- conn
+	}()
+
+	badClient, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+
+	_, err = badClient.Write([]byte("GET / HTTP/1.1\r\n"))
+	require.NoError(t, err)
+	_ = badClient.Close()
+
+	rawGoodClient, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rawGoodClient.Close() })
+
+	goodClient := tls.Client(rawGoodClient, &tls.Config{InsecureSkipVerify: true})
+
+	// goodClient.Write blocks until the TLS handshake completes, so it must run concurrently with the
+	// server side below rather than before it, or the two ends deadlock waiting on each other.
+	writeErrCh := make(chan error, 1)
+
+	go func() {
+		_, err := goodClient.Write([]byte("ping"))
+		writeErrCh <- err
+	}()
+
+	var server net.Conn
+
+	select {
+	case server = <-acceptCh:
+	case err := <-acceptErrCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Accept did not survive the bad probe and return the good connection")
+	}
+
+	t.Cleanup(func() { _ = server.Close() })
+
+	require.IsType(t, &tls.Conn{}, server)
+
+	buf := make([]byte, 4)
+	_, err = server.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buf))
+
+	select {
+	case err := <-writeErrCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("client handshake/write did not complete")
+	}
+}
+
+// newTCPListener returns a loopback TCP listener and its dial address.
+func newTCPListener(t *testing.T) (net.Listener, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return ln, ln.Addr().String()
+}
+
+// newTestTLSConfig returns a server tls.Config backed by a freshly generated self-signed certificate.
+func newTestTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tcp-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert := tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}}
+}
@@ -0,0 +1,53 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"net"
+)
+
+// Conn wraps a net.Conn (typically a *tls.Conn) together with the underlying *net.TCPConn extracted via
+// ExtractConn, so that callers which hijack an HTTP connection can half-close it. This is needed because
+// *tls.Conn has no way to shut down only one direction of the stream, which API handlers (exec, console,
+// file push) rely on to signal EOF to the peer's stdin without tearing down the read half.
+type Conn struct {
+	net.Conn
+
+	tcp *net.TCPConn
+}
+
+// NewConn extracts the underlying *net.TCPConn from conn (using ExtractConn) and returns a Conn that
+// exposes CloseWrite and CloseRead by delegating to it.
+func NewConn(conn net.Conn) (*Conn, error) {
+	tcpConn, err := ExtractConn(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conn{Conn: conn, tcp: tcpConn}, nil
+}
+
+// CloseWrite shuts down the write side of the connection. If the wrapped connection is a *tls.Conn, it first
+// sends a TLS close_notify alert on the write side so the peer's TLS stack is told no more application data
+// is coming, then closes the write half of the underlying TCP socket so the peer observes EOF on read.
+func (c *Conn) CloseWrite() error {
+	tlsConn, ok := c.Conn.(*tls.Conn)
+	if ok {
+		err := tlsConn.CloseWrite()
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.tcp.CloseWrite()
+}
+
+// CloseRead shuts down the read side of the underlying TCP socket, so further Reads on this side return EOF,
+// while leaving the write half of the connection open.
+func (c *Conn) CloseRead() error {
+	return c.tcp.CloseRead()
+}
+
+// Unwrap returns the net.Conn this Conn wraps, so ExtractConn can see through it to the real socket.
+func (c *Conn) Unwrap() net.Conn {
+	return c.Conn
+}
@@ -0,0 +1,72 @@
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"unsafe"
+)
+
+// Unwrapper is implemented by connection wrappers introduced by this module (the hijack half-close wrapper
+// Conn and the TLS-autodetect peek wrapper) so that ExtractConn can see through any number of stacked layers
+// down to the real socket.
+type Unwrapper interface {
+	Unwrap() net.Conn
+}
+
+// netConner is implemented by *tls.Conn since Go 1.18, returning the net.Conn it was constructed with.
+type netConner interface {
+	NetConn() net.Conn
+}
+
+// ExtractConn extracts the underlying *net.TCPConn from conn, unwrapping any number of stacked layers (TLS,
+// this module's own wrappers, or anything implementing Unwrapper) to get there. It tries the well-behaved
+// paths first - the NetConn() method that *tls.Conn has exposed since Go 1.18, and then the Unwrap() contract
+// - before falling back to the reflect/unsafe path for exotic types that predate both.
+func ExtractConn(conn net.Conn) (*net.TCPConn, error) {
+	for {
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			return tcpConn, nil
+		}
+
+		if nc, ok := conn.(netConner); ok {
+			conn = nc.NetConn()
+			continue
+		}
+
+		if uw, ok := conn.(Unwrapper); ok {
+			conn = uw.Unwrap()
+			continue
+		}
+
+		if tcpConn, ok := extractConnReflect(conn); ok {
+			return tcpConn, nil
+		}
+
+		return nil, fmt.Errorf("Unable to extract net.TCPConn from %T", conn)
+	}
+}
+
+// extractConnReflect is the historical fallback for connection types that predate both the NetConn() method
+// and the Unwrap() contract: it reaches into a private "conn" field via reflect/unsafe, which is how
+// *tls.Conn had to be handled before Go 1.18 exposed NetConn().
+func extractConnReflect(conn net.Conn) (*net.TCPConn, bool) {
+	v := reflect.ValueOf(conn)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	field := v.Elem().FieldByName("conn")
+	if !field.IsValid() {
+		return nil, false
+	}
+
+	field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+
+	tcpConn, ok := field.Interface().(*net.TCPConn)
+	if !ok {
+		return nil, false
+	}
+
+	return tcpConn, true
+}
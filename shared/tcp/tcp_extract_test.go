@@ -0,0 +1,63 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractConn_StackedWrappers stacks a *Conn (hijack wrapper) over a *peekedConn (TLS-autodetect wrapper)
+// over a *tls.Conn to make sure ExtractConn unwraps every layer down to the real *net.TCPConn, and that the
+// extracted handle is still good enough for SetTimeouts to apply setsockopt calls to.
+func TestExtractConn_StackedWrappers(t *testing.T) {
+	tcpServer, tcpClient := newTCPConnPair(t)
+	t.Cleanup(func() { _ = tcpServer.Close(); _ = tcpClient.Close() })
+
+	tlsConn := tls.Client(tcpClient, &tls.Config{InsecureSkipVerify: true})
+	peeked := newPeekedConn(tlsConn)
+	wrapped := &Conn{Conn: peeked, tcp: tcpClient}
+
+	got, err := ExtractConn(wrapped)
+	require.NoError(t, err)
+	require.Equal(t, tcpClient, got)
+
+	err = SetTimeouts(got, DefaultTimeoutConfig())
+	require.NoError(t, err)
+}
+
+// newTCPConnPair returns a connected pair of *net.TCPConn over the loopback interface.
+func newTCPConnPair(t *testing.T) (*net.TCPConn, *net.TCPConn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+
+	acceptCh := make(chan net.Conn, 1)
+	acceptErrCh := make(chan error, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			acceptErrCh <- err
+			return
+		}
+
+		acceptCh <- conn
+	}()
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+
+	var server net.Conn
+
+	select {
+	case server = <-acceptCh:
+	case err := <-acceptErrCh:
+		require.NoError(t, err)
+	}
+
+	return server.(*net.TCPConn), client.(*net.TCPConn)
+}
@@ -0,0 +1,139 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"time"
+)
+
+// defaultTLSWriteDeadline is the value used for ProxyOptions.TLSWriteDeadline when it is zero or negative.
+const defaultTLSWriteDeadline = 5 * time.Second
+
+// ProxyOptions controls the behaviour of Proxy.
+type ProxyOptions struct {
+	// TLSWriteDeadline is set as a *tls.Conn destination's write deadline just before its CloseWrite, once
+	// the other side has reached EOF. Defaults to 5s if zero or negative. (*tls.Conn).CloseWrite replaces any
+	// write deadline with its own fixed 5s while it sends close_notify, so this value does not change how
+	// long that send may block.
+	TLSWriteDeadline time.Duration
+}
+
+// ProxyResult reports the outcome of a Proxy call.
+type ProxyResult struct {
+	// BytesAToB is the number of bytes copied from a to b.
+	BytesAToB int64
+
+	// BytesBToA is the number of bytes copied from b to a.
+	BytesBToA int64
+}
+
+// Proxy shuttles bytes bidirectionally between a and b until ctx is cancelled or both directions have
+// reached EOF, performing a correct half-close on each side as its source reaches EOF: a *net.TCPConn or Conn
+// source has CloseRead called on it; a *tls.Conn source is left alone, since it's a single duplex stream
+// shared with the copy running in the other direction. On the destination side, CloseWrite is called on a
+// *net.TCPConn or Conn, or, for a *tls.Conn, a close_notify is sent via CloseWrite, whose send crypto/tls
+// bounds with a 5s write deadline so a dead peer can't wedge the goroutine forever. It returns aggregate byte counts
+// and the first non-EOF error encountered on either side, and tears down both conns as soon as ctx is
+// cancelled or either direction fails.
+func Proxy(ctx context.Context, a net.Conn, b net.Conn, opts ProxyOptions) (ProxyResult, error) {
+	if opts.TLSWriteDeadline <= 0 {
+		opts.TLSWriteDeadline = defaultTLSWriteDeadline
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errCh := make(chan error, 2)
+	result := ProxyResult{}
+
+	go func() {
+		// copyHalf(a, b, opts) copies from src b into dst a, i.e. the B-to-A direction.
+		n, err := copyHalf(a, b, opts)
+		result.BytesBToA = n
+		errCh <- err
+	}()
+
+	go func() {
+		// copyHalf(b, a, opts) copies from src a into dst b, i.e. the A-to-B direction.
+		n, err := copyHalf(b, a, opts)
+		result.BytesAToB = n
+		errCh <- err
+	}()
+
+	var firstErr error
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errCh:
+			if err != nil && firstErr == nil {
+				firstErr = err
+
+				// A real (non-EOF) error on one direction means the other direction's conns are no
+				// longer usable either; close both now rather than waiting on a source that may never
+				// reach EOF on its own, which would otherwise leak the sibling goroutine and its fds.
+				_ = a.Close()
+				_ = b.Close()
+			}
+		case <-ctx.Done():
+			_ = a.Close()
+			_ = b.Close()
+
+			if firstErr == nil {
+				firstErr = ctx.Err()
+			}
+
+			// Closing both conns unblocks the copy goroutines; wait for the remaining ones so the byte
+			// counts are final before result is returned.
+			for ; i < 2; i++ {
+				<-errCh
+			}
+		}
+	}
+
+	return result, firstErr
+}
+
+// copyHalf copies from src to dst until src reaches EOF, then half-closes both sides appropriately, and
+// returns the number of bytes copied and the first non-EOF error, if any.
+func copyHalf(dst net.Conn, src net.Conn, opts ProxyOptions) (int64, error) {
+	n, err := io.Copy(dst, src)
+
+	closeRead(src)
+	closeWrite(dst, opts)
+
+	if err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// closeRead half-closes the read side of src once it has reached EOF. For a *net.TCPConn or the hijack
+// wrapper this is a literal half-close. A *tls.Conn has no equivalent: it's a single duplex stream, and src
+// here is shared with the sibling copyHalf goroutine copying in the other direction, so sending our own
+// close_notify on read-EOF would mark that conn shut down for writes too and break the still-active other
+// direction. There's nothing meaningful to do here for *tls.Conn; closeWrite(dst, opts) on the other
+// goroutine already sends close_notify on the direction that actually finished.
+func closeRead(src net.Conn) {
+	switch c := src.(type) {
+	case *net.TCPConn:
+		_ = c.CloseRead()
+	case *Conn:
+		_ = c.CloseRead()
+	}
+}
+
+// closeWrite half-closes the write side of dst so the peer observes EOF, once src has reached EOF.
+func closeWrite(dst net.Conn, opts ProxyOptions) {
+	switch c := dst.(type) {
+	case *net.TCPConn:
+		_ = c.CloseWrite()
+	case *tls.Conn:
+		_ = c.SetWriteDeadline(time.Now().Add(opts.TLSWriteDeadline))
+		_ = c.CloseWrite()
+	case *Conn:
+		_ = c.CloseWrite()
+	}
+}
@@ -0,0 +1,71 @@
+package tcp
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestProxy_Directions bridges two independent *net.TCPConn pairs with Proxy and writes known payloads in
+// both directions, to make sure BytesAToB/BytesBToA are attributed to the direction they actually travelled
+// in and not swapped, and that each client observes a clean EOF once its peer's side is done.
+func TestProxy_Directions(t *testing.T) {
+	aServer, aClient := newTCPConnPair(t)
+	bServer, bClient := newTCPConnPair(t)
+	t.Cleanup(func() { _ = aClient.Close(); _ = bClient.Close() })
+
+	resultCh := make(chan struct {
+		result ProxyResult
+		err    error
+	}, 1)
+
+	go func() {
+		result, err := Proxy(context.Background(), aServer, bServer, ProxyOptions{})
+		resultCh <- struct {
+			result ProxyResult
+			err    error
+		}{result, err}
+	}()
+
+	aToB := []byte("from a to b")
+	bToA := []byte("from b to a, and longer")
+
+	_, err := aClient.Write(aToB)
+	require.NoError(t, err)
+
+	got := make([]byte, len(aToB))
+	_, err = io.ReadFull(bClient, got)
+	require.NoError(t, err)
+	require.Equal(t, aToB, got)
+
+	_, err = bClient.Write(bToA)
+	require.NoError(t, err)
+
+	got = make([]byte, len(bToA))
+	_, err = io.ReadFull(aClient, got)
+	require.NoError(t, err)
+	require.Equal(t, bToA, got)
+
+	// Half-close each client in turn so both copyHalf goroutines reach EOF and Proxy returns.
+	require.NoError(t, aClient.CloseWrite())
+
+	_, err = io.ReadAll(bClient)
+	require.NoError(t, err)
+
+	require.NoError(t, bClient.CloseWrite())
+
+	_, err = io.ReadAll(aClient)
+	require.NoError(t, err)
+
+	select {
+	case got := <-resultCh:
+		require.NoError(t, got.err)
+		require.Equal(t, int64(len(aToB)), got.result.BytesAToB)
+		require.Equal(t, int64(len(bToA)), got.result.BytesBToA)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Proxy did not return after both sides reached EOF")
+	}
+}
@@ -0,0 +1,67 @@
+//go:build linux
+
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// SetUserTimeout sets TCP_USER_TIMEOUT, the maximum amount of time in ms that transmitted data may remain
+// unacknowledged before the connection is forcefully closed and ETIMEDOUT is returned to the application.
+func SetUserTimeout(conn *net.TCPConn, timeout time.Duration) error {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sysErr error
+
+	err = rawConn.Control(func(fd uintptr) {
+		sysErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, int(timeout.Milliseconds()))
+	})
+	if err != nil {
+		return err
+	}
+
+	return sysErr
+}
+
+// SetKeepAliveCount sets TCP_KEEPINTVL (the interval between successive keepalive probes) and TCP_KEEPCNT
+// (the number of unacknowledged probes sent before the connection is declared dead) on conn. A zero interval
+// or count leaves the corresponding option untouched so callers can tune just one of the two. TCP_KEEPINTVL is
+// specified in whole seconds, so a positive interval under one second is rejected rather than silently
+// truncated to 0, which would otherwise flood the peer with back-to-back keepalive probes.
+func SetKeepAliveCount(conn *net.TCPConn, interval time.Duration, count int) error {
+	if interval > 0 && interval < time.Second {
+		return fmt.Errorf("keepalive interval %s is below the 1s TCP_KEEPINTVL resolution", interval)
+	}
+
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sysErr error
+
+	err = rawConn.Control(func(fd uintptr) {
+		if interval > 0 {
+			sysErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPINTVL, int(interval.Seconds()))
+			if sysErr != nil {
+				return
+			}
+		}
+
+		if count > 0 {
+			sysErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPCNT, count)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return sysErr
+}
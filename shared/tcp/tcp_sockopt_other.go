@@ -0,0 +1,20 @@
+//go:build !linux
+
+package tcp
+
+import (
+	"net"
+	"time"
+)
+
+// SetUserTimeout is a no-op on platforms other than Linux, which don't expose TCP_USER_TIMEOUT through a
+// portable API.
+func SetUserTimeout(conn *net.TCPConn, timeout time.Duration) error {
+	return nil
+}
+
+// SetKeepAliveCount is a no-op on platforms other than Linux, which don't expose TCP_KEEPINTVL/TCP_KEEPCNT
+// through a portable API. Callers that need this level of tuning must run on Linux.
+func SetKeepAliveCount(conn *net.TCPConn, interval time.Duration, count int) error {
+	return nil
+}
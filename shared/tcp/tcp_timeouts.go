@@ -1,38 +1,43 @@
 package tcp
 
 import (
-	"crypto/tls"
-	"fmt"
 	"net"
-	"reflect"
 	"time"
-	"unsafe"
 )
 
-// ExtractConn tries to extract the underlying net.TCPConn from a tls.Conn.
-func ExtractConn(conn net.Conn) (*net.TCPConn, error) {
-	// Go doesn't currently expose the underlying TCP connection of a TLS connection, but we need it in order
-	// to set timeout properties on the connection. We use some reflect/unsafe magic to extract the private
-	// remote.conn field, which is indeed the underlying TCP connection.
-	tlsConn, ok := conn.(*tls.Conn)
-	if !ok {
-		return nil, fmt.Errorf("Connection is not a tls.Conn")
+// DefaultTimeoutConfig returns the historical hard-coded defaults: a 30s TCP_USER_TIMEOUT, a 3s keepalive
+// period, and no explicit keepalive count/interval override (left to the OS default).
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		UserTimeout:       30 * time.Second,
+		KeepAliveIdle:     3 * time.Second,
+		KeepAliveInterval: 0,
+		KeepAliveCount:    0,
 	}
+}
 
-	field := reflect.ValueOf(tlsConn).Elem().FieldByName("conn")
-	field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
-	c := field.Interface()
+// TimeoutConfig holds the TCP_USER_TIMEOUT and keepalive tuning applied by SetTimeouts. KeepAliveInterval and
+// KeepAliveCount are optional (zero means "leave the OS default in place") and let operators tune how many
+// probes are sent, and how far apart, before a dead peer's socket is declared dead, matching the knobs
+// etcd/k8s clients expose for their own health-check keepalives.
+type TimeoutConfig struct {
+	// UserTimeout is the TCP_USER_TIMEOUT: the maximum time transmitted data may remain unacknowledged
+	// before the connection is forcefully closed with ETIMEDOUT.
+	UserTimeout time.Duration
 
-	tcpConn, ok := c.(*net.TCPConn)
-	if !ok {
-		return nil, fmt.Errorf("Connection is not a net.TCPConn")
-	}
+	// KeepAliveIdle is how long the connection must be idle before the first keepalive probe is sent.
+	KeepAliveIdle time.Duration
+
+	// KeepAliveInterval is the time between successive keepalive probes. Zero leaves the OS default.
+	KeepAliveInterval time.Duration
 
-	return tcpConn, nil
+	// KeepAliveCount is the number of unacknowledged probes sent before the connection is declared dead.
+	// Zero leaves the OS default.
+	KeepAliveCount int
 }
 
-// SetTimeouts sets TCP_USER_TIMEOUT and TCP keep alive timeouts on a connection.
-func SetTimeouts(conn *net.TCPConn) error {
+// SetTimeouts sets TCP_USER_TIMEOUT and TCP keep alive timeouts on a connection using the provided config.
+func SetTimeouts(conn *net.TCPConn, cfg TimeoutConfig) error {
 	// Set TCP_USER_TIMEOUT option to limit the maximum amount of time in ms that transmitted data may remain
 	// unacknowledged before TCP will forcefully close the corresponding connection and return ETIMEDOUT to the
 	// application. This combined with the TCP keepalive options on the socket will ensure that should the
@@ -41,7 +46,7 @@ func SetTimeouts(conn *net.TCPConn) error {
 	// up to 20 minutes with the current system defaults in a normal WAN environment if there are packets in
 	// the send queue that will prevent the keepalive timer from working as the retransmission timers kick in.
 	// See https://git.kernel.org/pub/scm/linux/kernel/git/torvalds/linux.git/commit/?id=dca43c75e7e545694a9dd6288553f55c53e2a3a3
-	err := SetUserTimeout(conn, time.Second*30)
+	err := SetUserTimeout(conn, cfg.UserTimeout)
 	if err != nil {
 		return err
 	}
@@ -51,10 +56,19 @@ func SetTimeouts(conn *net.TCPConn) error {
 		return err
 	}
 
-	err = conn.SetKeepAlivePeriod(3 * time.Second)
+	err = conn.SetKeepAlivePeriod(cfg.KeepAliveIdle)
 	if err != nil {
 		return err
 	}
 
+	// KeepAliveInterval and KeepAliveCount aren't exposed by the standard library's net.TCPConn, so they're
+	// set via raw TCP_KEEPINTVL/TCP_KEEPCNT setsockopt calls when the caller asked for a non-default value.
+	if cfg.KeepAliveInterval > 0 || cfg.KeepAliveCount > 0 {
+		err = SetKeepAliveCount(conn, cfg.KeepAliveInterval, cfg.KeepAliveCount)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }